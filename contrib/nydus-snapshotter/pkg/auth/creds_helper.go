@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// credHelperPrefix is prepended to a helper name to find its binary on
+// PATH, following the Docker/Podman `docker-credential-<name>` convention.
+const credHelperPrefix = "docker-credential-"
+
+// tokenUsername is the sentinel username credential helpers use to signal
+// that Secret is a registry token rather than a password.
+const tokenUsername = "<token>"
+
+// CredsHelper execs a `docker-credential-<name>` binary that speaks the
+// standard get/store/erase JSON protocol on stdin/stdout.
+type CredsHelper struct {
+	Name string
+}
+
+// credsHelperOutput mirrors the JSON a credential helper writes to stdout
+// in response to a `get` request.
+type credsHelperOutput struct {
+	Username string
+	Secret   string
+}
+
+// FromCredsHelper runs `docker-credential-<helper> get` with `host` as the
+// server URL on stdin and converts the result into a PassKeyChain. The
+// special `<token>` username maps to a token-based PassKeyChain.
+func FromCredsHelper(helper, host string) (*PassKeyChain, error) {
+	return CredsHelper{Name: helper}.Get(host)
+}
+
+// Get asks the helper for credentials for `host`.
+func (h CredsHelper) Get(host string) (*PassKeyChain, error) {
+	bin := credHelperPrefix + h.Name
+
+	cmd := exec.Command(bin, "get")
+	cmd.Stdin = bytes.NewBufferString(host)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("exec %s get: %w", bin, err)
+	}
+
+	var out credsHelperOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("parse %s output: %w", bin, err)
+	}
+
+	if out.Username == tokenUsername {
+		return &PassKeyChain{Password: out.Secret}, nil
+	}
+
+	return &PassKeyChain{
+		Username: out.Username,
+		Password: out.Secret,
+	}, nil
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// installFakeCredHelper writes a `docker-credential-<name>` script that
+// echoes `output` to stdout and prepends its directory to PATH for the
+// duration of the test.
+func installFakeCredHelper(t *testing.T, name, output string) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake credential helper script is shell-based")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, credHelperPrefix+name)
+	script := fmt.Sprintf("#!/bin/sh\ncat >/dev/null\ncat <<'EOF'\n%s\nEOF\n", output)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake helper: %v", err)
+	}
+
+	oldPath := os.Getenv("PATH")
+	if err := os.Setenv("PATH", dir+string(os.PathListSeparator)+oldPath); err != nil {
+		t.Fatalf("set PATH: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Setenv("PATH", oldPath)
+	})
+}
+
+func TestFromCredsHelper(t *testing.T) {
+	installFakeCredHelper(t, "test", `{"Username":"alice","Secret":"hunter2"}`)
+
+	kc, err := FromCredsHelper("test", "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kc.Username != "alice" || kc.Password != "hunter2" {
+		t.Fatalf("unexpected keychain: %+v", kc)
+	}
+}
+
+func TestFromCredsHelperToken(t *testing.T) {
+	installFakeCredHelper(t, "token", `{"Username":"<token>","Secret":"tok-123"}`)
+
+	kc, err := FromCredsHelper("token", "registry.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !kc.TokenBase() || kc.Password != "tok-123" {
+		t.Fatalf("expected a token-based keychain, got: %+v", kc)
+	}
+}
+
+func TestFromCredsHelperMissingBinary(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir)
+	t.Cleanup(func() { os.Setenv("PATH", oldPath) })
+
+	if _, err := FromCredsHelper("does-not-exist", "registry.example.com"); err == nil {
+		t.Fatal("expected an error when the helper binary is not on PATH")
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// defaultImageServiceAddress is the Unix socket containerd exposes its CRI
+// ImageService on.
+const defaultImageServiceAddress = "/run/containerd/containerd.sock"
+
+// criCredsKey identifies a captured PullImage AuthConfig by registry host
+// and the exact image reference it was pulled with.
+type criCredsKey struct {
+	host string
+	ref  string
+}
+
+// imageProxy sits in front of containerd's real ImageService and captures
+// the AuthConfig of every PullImage request it forwards, so credentials
+// supplied via Kubernetes `imagePullSecrets` are observable even though the
+// snapshotter never sees the pod's Secret objects directly.
+type imageProxy struct {
+	runtime.UnimplementedImageServiceServer
+
+	upstream runtime.ImageServiceClient
+
+	mu    sync.RWMutex
+	store map[criCredsKey]PassKeyChain
+}
+
+// defaultImageProxyMu guards defaultImageProxy itself (not just the imageProxy's
+// own internal state), so AddImageProxy can race safely with a concurrent
+// FromCRI lookup during snapshotter startup.
+var defaultImageProxyMu sync.RWMutex
+var defaultImageProxy *imageProxy
+
+// AddImageProxy dials the real ImageService at `imageServiceAddress` and
+// registers a proxying ImageServiceServer on `rpc` that intercepts PullImage
+// calls to capture their AuthConfig.
+func AddImageProxy(ctx context.Context, rpc *grpc.Server, imageServiceAddress string) error {
+	if imageServiceAddress == "" {
+		imageServiceAddress = defaultImageServiceAddress
+	}
+
+	conn, err := grpc.DialContext(ctx, "unix://"+imageServiceAddress,
+		grpc.WithInsecure(), //nolint:staticcheck
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("dial image service %s: %w", imageServiceAddress, err)
+	}
+
+	proxy := &imageProxy{
+		upstream: runtime.NewImageServiceClient(conn),
+		store:    make(map[criCredsKey]PassKeyChain),
+	}
+
+	defaultImageProxyMu.Lock()
+	defaultImageProxy = proxy
+	defaultImageProxyMu.Unlock()
+
+	runtime.RegisterImageServiceServer(rpc, proxy)
+
+	return nil
+}
+
+// PullImage forwards the request to the real ImageService and, on success,
+// captures the request's AuthConfig keyed by registry host and image
+// reference.
+func (p *imageProxy) PullImage(ctx context.Context, req *runtime.PullImageRequest) (*runtime.PullImageResponse, error) {
+	resp, err := p.upstream.PullImage(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	ref := req.GetImage().GetImage()
+	host, parseErr := hostFromRef(ref)
+	if parseErr != nil {
+		logrus.WithError(parseErr).WithField("ref", ref).Warn("skip caching CRI auth config")
+		return resp, err
+	}
+
+	auth := req.GetAuth()
+	if auth == nil {
+		return resp, err
+	}
+
+	kc := PassKeyChain{
+		Username: auth.GetUsername(),
+		Password: auth.GetPassword(),
+	}
+	if auth.GetIdentityToken() != "" {
+		kc = PassKeyChain{Password: auth.GetIdentityToken()}
+	}
+
+	p.mu.Lock()
+	p.store[criCredsKey{host: host, ref: ref}] = kc
+	p.mu.Unlock()
+
+	return resp, err
+}
+
+// ListImages forwards unmodified to the real ImageService; this proxy only
+// needs to observe PullImage.
+func (p *imageProxy) ListImages(ctx context.Context, req *runtime.ListImagesRequest) (*runtime.ListImagesResponse, error) {
+	return p.upstream.ListImages(ctx, req)
+}
+
+// ImageStatus forwards unmodified to the real ImageService.
+func (p *imageProxy) ImageStatus(ctx context.Context, req *runtime.ImageStatusRequest) (*runtime.ImageStatusResponse, error) {
+	return p.upstream.ImageStatus(ctx, req)
+}
+
+// RemoveImage forwards unmodified to the real ImageService.
+func (p *imageProxy) RemoveImage(ctx context.Context, req *runtime.RemoveImageRequest) (*runtime.RemoveImageResponse, error) {
+	return p.upstream.RemoveImage(ctx, req)
+}
+
+// ImageFsInfo forwards unmodified to the real ImageService.
+func (p *imageProxy) ImageFsInfo(ctx context.Context, req *runtime.ImageFsInfoRequest) (*runtime.ImageFsInfoResponse, error) {
+	return p.upstream.ImageFsInfo(ctx, req)
+}
+
+// FromCRI looks up the AuthConfig captured from a containerd PullImage
+// request for `host`/`ref`.
+func FromCRI(host, ref string) (*PassKeyChain, error) {
+	defaultImageProxyMu.RLock()
+	proxy := defaultImageProxy
+	defaultImageProxyMu.RUnlock()
+
+	if proxy == nil {
+		return nil, nil
+	}
+
+	proxy.mu.RLock()
+	defer proxy.mu.RUnlock()
+
+	kc, found := proxy.store[criCredsKey{host: host, ref: ref}]
+	if !found {
+		return nil, nil
+	}
+	return &kc, nil
+}
+
+func hostFromRef(ref string) (string, error) {
+	if ref == "" {
+		return "", fmt.Errorf("empty image reference")
+	}
+
+	name := ref
+	if idx := indexOfAny(name, []byte{'/'}); idx >= 0 {
+		candidate := name[:idx]
+		if indexOfAny(candidate, []byte{'.', ':'}) >= 0 || candidate == "localhost" {
+			return candidate, nil
+		}
+	}
+	return "docker.io", nil
+}
+
+func indexOfAny(s string, chars []byte) int {
+	for i := 0; i < len(s); i++ {
+		for _, c := range chars {
+			if s[i] == c {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func TestHostFromRef(t *testing.T) {
+	cases := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "library/nginx:latest", want: "docker.io"},
+		{ref: "nginx", want: "docker.io"},
+		{ref: "registry.example.com/library/nginx:latest", want: "registry.example.com"},
+		{ref: "registry.example.com:5000/library/nginx:latest", want: "registry.example.com:5000"},
+		{ref: "localhost/nginx:latest", want: "localhost"},
+		{ref: "localhost:5000/nginx:latest", want: "localhost:5000"},
+	}
+
+	for _, tc := range cases {
+		got, err := hostFromRef(tc.ref)
+		if err != nil {
+			t.Fatalf("hostFromRef(%q): unexpected error: %v", tc.ref, err)
+		}
+		if got != tc.want {
+			t.Fatalf("hostFromRef(%q) = %q, want %q", tc.ref, got, tc.want)
+		}
+	}
+}
+
+func TestHostFromRefEmpty(t *testing.T) {
+	if _, err := hostFromRef(""); err == nil {
+		t.Fatal("expected an error for an empty reference")
+	}
+}
+
+// fakeImageServiceClient implements runtime.ImageServiceClient so PullImage
+// capture can be tested without a real containerd socket.
+type fakeImageServiceClient struct {
+	pullResp *runtime.PullImageResponse
+	pullErr  error
+}
+
+var _ runtime.ImageServiceClient = (*fakeImageServiceClient)(nil)
+
+func (f *fakeImageServiceClient) ListImages(ctx context.Context, in *runtime.ListImagesRequest, opts ...grpc.CallOption) (*runtime.ListImagesResponse, error) {
+	return &runtime.ListImagesResponse{}, nil
+}
+
+func (f *fakeImageServiceClient) ImageStatus(ctx context.Context, in *runtime.ImageStatusRequest, opts ...grpc.CallOption) (*runtime.ImageStatusResponse, error) {
+	return &runtime.ImageStatusResponse{}, nil
+}
+
+func (f *fakeImageServiceClient) PullImage(ctx context.Context, in *runtime.PullImageRequest, opts ...grpc.CallOption) (*runtime.PullImageResponse, error) {
+	return f.pullResp, f.pullErr
+}
+
+func (f *fakeImageServiceClient) RemoveImage(ctx context.Context, in *runtime.RemoveImageRequest, opts ...grpc.CallOption) (*runtime.RemoveImageResponse, error) {
+	return &runtime.RemoveImageResponse{}, nil
+}
+
+func (f *fakeImageServiceClient) ImageFsInfo(ctx context.Context, in *runtime.ImageFsInfoRequest, opts ...grpc.CallOption) (*runtime.ImageFsInfoResponse, error) {
+	return &runtime.ImageFsInfoResponse{}, nil
+}
+
+func TestImageProxyPullImageCapturesAuth(t *testing.T) {
+	proxy := &imageProxy{
+		upstream: &fakeImageServiceClient{pullResp: &runtime.PullImageResponse{ImageRef: "sha256:abc"}},
+		store:    make(map[criCredsKey]PassKeyChain),
+	}
+
+	const ref = "registry.example.com/library/nginx:latest"
+	req := &runtime.PullImageRequest{
+		Image: &runtime.ImageSpec{Image: ref},
+		Auth:  &runtime.AuthConfig{Username: "alice", Password: "hunter2"},
+	}
+
+	resp, err := proxy.PullImage(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ImageRef != "sha256:abc" {
+		t.Fatalf("expected the upstream response to be forwarded, got: %+v", resp)
+	}
+
+	proxy.mu.RLock()
+	kc, found := proxy.store[criCredsKey{host: "registry.example.com", ref: ref}]
+	proxy.mu.RUnlock()
+	if !found || kc.Username != "alice" || kc.Password != "hunter2" {
+		t.Fatalf("PullImage did not capture the AuthConfig, store=%+v", proxy.store)
+	}
+}
+
+func TestImageProxyPullImageCapturesIdentityToken(t *testing.T) {
+	proxy := &imageProxy{
+		upstream: &fakeImageServiceClient{pullResp: &runtime.PullImageResponse{}},
+		store:    make(map[criCredsKey]PassKeyChain),
+	}
+
+	const ref = "registry.example.com/library/nginx:latest"
+	req := &runtime.PullImageRequest{
+		Image: &runtime.ImageSpec{Image: ref},
+		Auth:  &runtime.AuthConfig{IdentityToken: "tok-123"},
+	}
+
+	if _, err := proxy.PullImage(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	proxy.mu.RLock()
+	kc, found := proxy.store[criCredsKey{host: "registry.example.com", ref: ref}]
+	proxy.mu.RUnlock()
+	if !found || !kc.TokenBase() || kc.Password != "tok-123" {
+		t.Fatalf("PullImage did not capture the identity token as a token-based keychain, got: %+v", kc)
+	}
+}
+
+func TestImageProxyPullImageUpstreamError(t *testing.T) {
+	wantErr := context.DeadlineExceeded
+	proxy := &imageProxy{
+		upstream: &fakeImageServiceClient{pullErr: wantErr},
+		store:    make(map[criCredsKey]PassKeyChain),
+	}
+
+	req := &runtime.PullImageRequest{
+		Image: &runtime.ImageSpec{Image: "registry.example.com/library/nginx:latest"},
+		Auth:  &runtime.AuthConfig{Username: "alice", Password: "hunter2"},
+	}
+
+	if _, err := proxy.PullImage(context.Background(), req); err != wantErr {
+		t.Fatalf("expected the upstream error to be forwarded, got: %v", err)
+	}
+	if len(proxy.store) != 0 {
+		t.Fatalf("a failed pull should not capture credentials, store=%+v", proxy.store)
+	}
+}
+
+func TestFromCRIUninitialized(t *testing.T) {
+	defaultImageProxyMu.Lock()
+	old := defaultImageProxy
+	defaultImageProxy = nil
+	defaultImageProxyMu.Unlock()
+	t.Cleanup(func() {
+		defaultImageProxyMu.Lock()
+		defaultImageProxy = old
+		defaultImageProxyMu.Unlock()
+	})
+
+	kc, err := FromCRI("registry.example.com", "registry.example.com/library/nginx:latest")
+	if err != nil || kc != nil {
+		t.Fatalf("expected (nil, nil) before AddImageProxy runs, got (%+v, %v)", kc, err)
+	}
+}
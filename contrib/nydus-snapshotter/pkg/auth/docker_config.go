@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dockerConfigPath is the location of the docker CLI config relative to the
+// user's home directory.
+const dockerConfigPath = ".docker/config.json"
+
+// dockerConfigFile mirrors the subset of `~/.docker/config.json` this
+// package cares about.
+type dockerConfigFile struct {
+	Auths       map[string]dockerConfigAuthEntry `json:"auths"`
+	CredsStore  string                           `json:"credsStore"`
+	CredHelpers map[string]string                `json:"credHelpers"`
+}
+
+type dockerConfigAuthEntry struct {
+	Auth string `json:"auth"`
+}
+
+// FromDockerConfig resolves credentials for `host` from `~/.docker/config.json`,
+// trying in order: a per-host `auths` entry, a per-host external credential
+// helper from `credHelpers`, and the global `credsStore` helper.
+func FromDockerConfig(host string) *PassKeyChain {
+	cfg, err := loadDockerConfig()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.WithError(err).Warn("failed to load docker config")
+		}
+		return nil
+	}
+
+	if entry, found := cfg.Auths[host]; found && entry.Auth != "" {
+		kc, err := FromBase64(entry.Auth)
+		if err != nil {
+			logrus.WithError(err).WithField("host", host).Warn("invalid docker config auth entry")
+		} else {
+			return &kc
+		}
+	}
+
+	if helper, found := cfg.CredHelpers[host]; found && helper != "" {
+		if kc, err := FromCredsHelper(helper, host); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"host": host, "helper": helper}).Warn("failed to resolve credHelpers entry")
+		} else if kc != nil {
+			return kc
+		}
+	}
+
+	if cfg.CredsStore != "" {
+		if kc, err := FromCredsHelper(cfg.CredsStore, host); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"host": host, "helper": cfg.CredsStore}).Warn("failed to resolve credsStore")
+		} else if kc != nil {
+			return kc
+		}
+	}
+
+	return nil
+}
+
+func loadDockerConfig() (*dockerConfigFile, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, dockerConfigPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg dockerConfigFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withHome points $HOME at a fresh temp directory for the duration of the
+// test, so FromDockerConfig resolves `~/.docker/config.json` to a fixture
+// instead of the real user's config.
+func withHome(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	old, hadOld := os.LookupEnv("HOME")
+	if err := os.Setenv("HOME", home); err != nil {
+		t.Fatalf("set HOME: %v", err)
+	}
+	t.Cleanup(func() {
+		if hadOld {
+			os.Setenv("HOME", old)
+		} else {
+			os.Unsetenv("HOME")
+		}
+	})
+
+	return home
+}
+
+func writeDockerConfig(t *testing.T, home, contents string) {
+	t.Helper()
+
+	dir := filepath.Join(home, ".docker")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatalf("mkdir %s: %v", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(contents), 0o600); err != nil {
+		t.Fatalf("write config.json: %v", err)
+	}
+}
+
+func TestFromDockerConfigAuthsEntry(t *testing.T) {
+	home := withHome(t)
+	writeDockerConfig(t, home, `{"auths":{"registry.example.com":{"auth":"`+authEntry("alice", "hunter2")+`"}}}`)
+
+	kc := FromDockerConfig("registry.example.com")
+	if kc == nil || kc.Username != "alice" || kc.Password != "hunter2" {
+		t.Fatalf("unexpected keychain: %+v", kc)
+	}
+}
+
+func TestFromDockerConfigNoFile(t *testing.T) {
+	withHome(t)
+
+	if kc := FromDockerConfig("registry.example.com"); kc != nil {
+		t.Fatalf("expected nil keychain when no docker config exists, got: %+v", kc)
+	}
+}
+
+func TestFromDockerConfigUnknownHost(t *testing.T) {
+	home := withHome(t)
+	writeDockerConfig(t, home, `{"auths":{"registry.example.com":{"auth":"`+authEntry("alice", "hunter2")+`"}}}`)
+
+	if kc := FromDockerConfig("other.example.com"); kc != nil {
+		t.Fatalf("expected nil keychain for a host with no entry, got: %+v", kc)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/sirupsen/logrus"
 
 	"github.com/dragonflyoss/image-service/contrib/nydus-snapshotter/pkg/label"
 )
@@ -80,13 +81,24 @@ func FromLabels(labels map[string]string) *PassKeyChain {
 }
 
 // GetRegistryKeyChain get image pull kaychain from (ordered):
-// 1. username and secrets labels
-// 2. docker config
+// 1. credentials captured from the CRI ImageService's PullImage requests
+// 2. username and secrets labels
+// 3. credentials synced from Kubernetes dockerconfigjson Secrets
+// 4. docker config, including per-host credHelpers/credsStore external
+//    credential helpers
 func GetRegistryKeyChain(host string, labels map[string]string) *PassKeyChain {
+	if kc, err := FromCRI(host, labels[label.CRIImageRef]); err != nil {
+		logrus.WithError(err).WithField("host", host).Warn("failed to look up CRI-captured credentials")
+	} else if kc != nil {
+		return kc
+	}
 	kc := FromLabels(labels)
 	if kc != nil {
 		return kc
 	}
+	if kc := GetCredentialsStore(host); kc != nil {
+		return kc
+	}
 	return FromDockerConfig(host)
 }
 
@@ -94,6 +106,39 @@ func (kc PassKeyChain) Resolve(target authn.Resource) (authn.Authenticator, erro
 	return authn.FromConfig(kc.toAuthConfig()), nil
 }
 
+// Options controls optional behavior of GetRegistryKeyChainWithOptions and
+// Resolve.
+type Options struct {
+	// SoftFail makes Resolve return authn.Anonymous instead of an error
+	// when no credentials are found for a host, so tasks pulling public
+	// images keep working while a global auth source is configured.
+	SoftFail bool
+}
+
+// GetRegistryKeyChainWithOptions is GetRegistryKeyChain with Options. A
+// PassKeyChain has no anonymous representation, so opts.SoftFail cannot
+// change what this function returns; it is threaded through only so
+// Resolve can apply it at the authn.Authenticator level it produces. Call
+// Resolve directly if you need the anonymous fallback.
+func GetRegistryKeyChainWithOptions(host string, labels map[string]string, opts Options) *PassKeyChain {
+	return GetRegistryKeyChain(host, labels)
+}
+
+// Resolve builds an authn.Authenticator for `host` from the keychain
+// GetRegistryKeyChainWithOptions resolves. When no credentials are found and
+// opts.SoftFail is set, it returns authn.Anonymous instead of an error, so
+// a missing per-host credential for a public image does not abort the pull.
+func Resolve(host string, labels map[string]string, opts Options) (authn.Authenticator, error) {
+	kc := GetRegistryKeyChainWithOptions(host, labels, opts)
+	if kc == nil {
+		if opts.SoftFail {
+			return authn.Anonymous, nil
+		}
+		return nil, fmt.Errorf("no credentials found for registry %s", host)
+	}
+	return kc.Resolve(nil)
+}
+
 // toAuthConfig convert PassKeyChain to authn.AuthConfig when kc is token based,
 // RegistryToken is preferred to
 func (kc PassKeyChain) toAuthConfig() authn.AuthConfig {
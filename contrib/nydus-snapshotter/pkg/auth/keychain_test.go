@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/dragonflyoss/image-service/contrib/nydus-snapshotter/pkg/label"
+)
+
+func TestFromBase64RoundTrip(t *testing.T) {
+	kc := PassKeyChain{Username: "alice", Password: "hunter2"}
+
+	got, err := FromBase64(kc.ToBase64())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != kc {
+		t.Fatalf("FromBase64(ToBase64()) = %+v, want %+v", got, kc)
+	}
+}
+
+func TestFromBase64Invalid(t *testing.T) {
+	noSep := base64.StdEncoding.EncodeToString([]byte("nosep"))
+	if _, err := FromBase64(noSep); err == nil {
+		t.Fatal("expected an error for a decoded value with no user:pass separator")
+	}
+}
+
+func TestTokenBase(t *testing.T) {
+	if (PassKeyChain{Password: "tok"}).TokenBase() != true {
+		t.Fatal("a keychain with only a password should be token-based")
+	}
+	if (PassKeyChain{Username: "alice", Password: "hunter2"}).TokenBase() != false {
+		t.Fatal("a keychain with both username and password should not be token-based")
+	}
+}
+
+func TestFromLabelsMissing(t *testing.T) {
+	if kc := FromLabels(map[string]string{}); kc != nil {
+		t.Fatalf("expected nil with no labels, got: %+v", kc)
+	}
+	if kc := FromLabels(map[string]string{label.ImagePullUsername: "alice"}); kc != nil {
+		t.Fatalf("expected nil with only a username label, got: %+v", kc)
+	}
+}
+
+func TestFromLabelsPresent(t *testing.T) {
+	kc := FromLabels(map[string]string{
+		label.ImagePullUsername: "alice",
+		label.ImagePullSecret:   "hunter2",
+	})
+	if kc == nil || kc.Username != "alice" || kc.Password != "hunter2" {
+		t.Fatalf("unexpected keychain: %+v", kc)
+	}
+}
+
+func TestGetRegistryKeyChainPrefersCRIOverLabels(t *testing.T) {
+	const host = "registry.example.com"
+	const ref = "registry.example.com/library/nginx:latest"
+
+	proxy := &imageProxy{
+		upstream: &fakeImageServiceClient{pullResp: &runtime.PullImageResponse{}},
+		store: map[criCredsKey]PassKeyChain{
+			{host: host, ref: ref}: {Username: "cri-user", Password: "cri-pass"},
+		},
+	}
+
+	defaultImageProxyMu.Lock()
+	old := defaultImageProxy
+	defaultImageProxy = proxy
+	defaultImageProxyMu.Unlock()
+	t.Cleanup(func() {
+		defaultImageProxyMu.Lock()
+		defaultImageProxy = old
+		defaultImageProxyMu.Unlock()
+	})
+
+	labels := map[string]string{
+		label.CRIImageRef:       ref,
+		label.ImagePullUsername: "label-user",
+		label.ImagePullSecret:   "label-pass",
+	}
+
+	kc := GetRegistryKeyChain(host, labels)
+	if kc == nil || kc.Username != "cri-user" || kc.Password != "cri-pass" {
+		t.Fatalf("expected CRI-captured credentials to win, got: %+v", kc)
+	}
+}
+
+func TestGetRegistryKeyChainPrefersLabelsOverKubeSecrets(t *testing.T) {
+	const host = "registry.example.com"
+
+	listener := &kubeSecretListener{
+		store: map[string]PassKeyChain{host: {Username: "kube-user", Password: "kube-pass"}},
+	}
+	defaultKubeSecretListenerMu.Lock()
+	old := defaultKubeSecretListener
+	defaultKubeSecretListener = listener
+	defaultKubeSecretListenerMu.Unlock()
+	t.Cleanup(func() {
+		defaultKubeSecretListenerMu.Lock()
+		defaultKubeSecretListener = old
+		defaultKubeSecretListenerMu.Unlock()
+	})
+
+	labels := map[string]string{
+		label.ImagePullUsername: "label-user",
+		label.ImagePullSecret:   "label-pass",
+	}
+
+	kc := GetRegistryKeyChain(host, labels)
+	if kc == nil || kc.Username != "label-user" || kc.Password != "label-pass" {
+		t.Fatalf("expected label credentials to win over kube secrets, got: %+v", kc)
+	}
+}
+
+func TestGetRegistryKeyChainFallsBackToKubeSecrets(t *testing.T) {
+	const host = "registry.example.com"
+
+	listener := &kubeSecretListener{
+		store: map[string]PassKeyChain{host: {Username: "kube-user", Password: "kube-pass"}},
+	}
+	defaultKubeSecretListenerMu.Lock()
+	old := defaultKubeSecretListener
+	defaultKubeSecretListener = listener
+	defaultKubeSecretListenerMu.Unlock()
+	t.Cleanup(func() {
+		defaultKubeSecretListenerMu.Lock()
+		defaultKubeSecretListener = old
+		defaultKubeSecretListenerMu.Unlock()
+	})
+
+	kc := GetRegistryKeyChain(host, map[string]string{})
+	if kc == nil || kc.Username != "kube-user" || kc.Password != "kube-pass" {
+		t.Fatalf("expected kube secret credentials, got: %+v", kc)
+	}
+}
+
+func TestResolveSoftFailReturnsAnonymous(t *testing.T) {
+	withHome(t) // no ~/.docker/config.json, so nothing resolves
+
+	auth, err := Resolve("registry.example.com", map[string]string{}, Options{SoftFail: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != authn.Anonymous {
+		t.Fatalf("expected authn.Anonymous, got: %+v", auth)
+	}
+}
+
+func TestResolveWithoutSoftFailReturnsError(t *testing.T) {
+	withHome(t)
+
+	if _, err := Resolve("registry.example.com", map[string]string{}, Options{}); err == nil {
+		t.Fatal("expected an error when no credentials are found and SoftFail is false")
+	}
+}
+
+func TestResolveWithCredentials(t *testing.T) {
+	labels := map[string]string{
+		label.ImagePullUsername: "alice",
+		label.ImagePullSecret:   "hunter2",
+	}
+
+	auth, err := Resolve("registry.example.com", labels, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth == nil {
+		t.Fatal("expected a non-nil authenticator")
+	}
+}
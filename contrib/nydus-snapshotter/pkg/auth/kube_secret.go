@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// dockerConfigJSONKey is the Secret data key used by
+	// `kubernetes.io/dockerconfigjson` Secrets.
+	dockerConfigJSONKey = ".dockerconfigjson"
+
+	// namespaceEnv lets operators pin the namespace the listener watches;
+	// it falls back to "default" when unset, e.g. when it isn't injected
+	// via the downward API.
+	namespaceEnv = "NYDUS_SNAPSHOTTER_SECRET_NAMESPACE"
+)
+
+// kubeSecretListener keeps an in-memory registry-host -> PassKeyChain map
+// synced from `kubernetes.io/dockerconfigjson` Secrets in a namespace.
+type kubeSecretListener struct {
+	mu        sync.RWMutex
+	client    kubernetes.Interface
+	namespace string
+	store     map[string]PassKeyChain
+}
+
+// defaultKubeSecretListenerMu guards defaultKubeSecretListener itself (not
+// just the kubeSecretListener's own internal state), so InitKubeSecretListener
+// can race safely with concurrent SyncKubeSecrets/GetCredentialsStore calls
+// during snapshotter startup.
+var defaultKubeSecretListenerMu sync.RWMutex
+var defaultKubeSecretListener *kubeSecretListener
+
+// InitKubeSecretListener builds a Kubernetes clientset — in-cluster config
+// when `kubeconfigPath` is empty, otherwise the kubeconfig at that path —
+// and performs an initial sync of dockerconfigjson Secrets found in the
+// configured namespace.
+func InitKubeSecretListener(ctx context.Context, kubeconfigPath string) error {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("build kube config: %w", err)
+	}
+
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("build kube clientset: %w", err)
+	}
+
+	namespace := os.Getenv(namespaceEnv)
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	listener := &kubeSecretListener{
+		client:    client,
+		namespace: namespace,
+		store:     make(map[string]PassKeyChain),
+	}
+
+	defaultKubeSecretListenerMu.Lock()
+	defaultKubeSecretListener = listener
+	defaultKubeSecretListenerMu.Unlock()
+
+	return SyncKubeSecrets(ctx)
+}
+
+// SyncKubeSecrets lists dockerconfigjson Secrets in the configured namespace
+// and refreshes the in-memory host -> PassKeyChain map. It is a no-op when
+// the listener has not been initialized.
+func SyncKubeSecrets(ctx context.Context) error {
+	defaultKubeSecretListenerMu.RLock()
+	listener := defaultKubeSecretListener
+	defaultKubeSecretListenerMu.RUnlock()
+
+	if listener == nil {
+		return nil
+	}
+	return listener.sync(ctx)
+}
+
+func (l *kubeSecretListener) sync(ctx context.Context) error {
+	secrets, err := l.client.CoreV1().Secrets(l.namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "type=kubernetes.io/dockerconfigjson",
+	})
+	if err != nil {
+		return fmt.Errorf("list secrets in namespace %s: %w", l.namespace, err)
+	}
+
+	store := make(map[string]PassKeyChain)
+	for _, secret := range secrets.Items {
+		if err := mergeDockerConfigSecret(store, secret); err != nil {
+			logrus.WithError(err).WithField("secret", secret.Name).Warn("skip dockerconfigjson secret")
+		}
+	}
+
+	l.mu.Lock()
+	l.store = store
+	l.mu.Unlock()
+
+	return nil
+}
+
+func mergeDockerConfigSecret(store map[string]PassKeyChain, secret corev1.Secret) error {
+	raw, found := secret.Data[dockerConfigJSONKey]
+	if !found {
+		return fmt.Errorf("missing %s key", dockerConfigJSONKey)
+	}
+
+	keychains, err := FromDockerConfigJSON(raw)
+	for host, kc := range keychains {
+		store[host] = kc
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", dockerConfigJSONKey, err)
+	}
+
+	return nil
+}
+
+// GetCredentialsStore returns the PassKeyChain synced from cluster Secrets
+// for `host`, or nil when the listener has not been initialized or holds no
+// credentials for that host.
+func GetCredentialsStore(host string) *PassKeyChain {
+	defaultKubeSecretListenerMu.RLock()
+	listener := defaultKubeSecretListener
+	defaultKubeSecretListenerMu.RUnlock()
+
+	if listener == nil {
+		return nil
+	}
+
+	listener.mu.RLock()
+	defer listener.mu.RUnlock()
+
+	kc, found := listener.store[host]
+	if !found {
+		return nil
+	}
+	return &kc
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMergeDockerConfigSecret(t *testing.T) {
+	store := make(map[string]PassKeyChain)
+	secret := corev1.Secret{
+		Data: map[string][]byte{
+			dockerConfigJSONKey: []byte(`{"auths":{"registry.example.com":{"auth":"` + authEntry("alice", "hunter2") + `"}}}`),
+		},
+	}
+
+	if err := mergeDockerConfigSecret(store, secret); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kc, found := store["registry.example.com"]
+	if !found || kc.Username != "alice" || kc.Password != "hunter2" {
+		t.Fatalf("unexpected store contents: %+v", store)
+	}
+}
+
+func TestMergeDockerConfigSecretMissingKey(t *testing.T) {
+	store := make(map[string]PassKeyChain)
+	if err := mergeDockerConfigSecret(store, corev1.Secret{}); err == nil {
+		t.Fatal("expected an error when the secret has no .dockerconfigjson key")
+	}
+}
+
+func TestKubeSecretListenerSync(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: "default"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			dockerConfigJSONKey: []byte(`{"auths":{"registry.example.com":{"auth":"` + authEntry("alice", "hunter2") + `"}}}`),
+		},
+	})
+
+	listener := &kubeSecretListener{
+		client:    client,
+		namespace: "default",
+		store:     make(map[string]PassKeyChain),
+	}
+
+	if err := listener.sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	listener.mu.RLock()
+	kc, found := listener.store["registry.example.com"]
+	listener.mu.RUnlock()
+	if !found || kc.Username != "alice" {
+		t.Fatalf("sync did not populate the store: %+v", listener.store)
+	}
+}
+
+func TestGetCredentialsStoreUninitialized(t *testing.T) {
+	defaultKubeSecretListenerMu.Lock()
+	old := defaultKubeSecretListener
+	defaultKubeSecretListener = nil
+	defaultKubeSecretListenerMu.Unlock()
+	t.Cleanup(func() {
+		defaultKubeSecretListenerMu.Lock()
+		defaultKubeSecretListener = old
+		defaultKubeSecretListenerMu.Unlock()
+	})
+
+	if kc := GetCredentialsStore("registry.example.com"); kc != nil {
+		t.Fatalf("expected nil before initialization, got: %+v", kc)
+	}
+}
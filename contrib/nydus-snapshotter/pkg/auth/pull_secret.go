@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// legacyDockerIOHost is the index server host older `.dockerconfigjson`
+// secrets use for Docker Hub; it is normalized to dockerIOHost.
+const legacyDockerIOHost = "https://index.docker.io/v1/"
+
+// dockerIOHost is the canonical host key used to look up Docker Hub
+// credentials.
+const dockerIOHost = "docker.io"
+
+// pullSecretAuths mirrors the `{"auths": {...}}` schema of a Kubernetes
+// `kubernetes.io/dockerconfigjson` Secret.
+type pullSecretAuths struct {
+	Auths map[string]pullSecretEntry `json:"auths"`
+}
+
+type pullSecretEntry struct {
+	Auth  string `json:"auth"`
+	Email string `json:"email,omitempty"`
+}
+
+// FromDockerConfigJSON parses a full Kubernetes `.dockerconfigjson` secret
+// payload into a host -> PassKeyChain map. Per-host errors (malformed
+// base64, entries that don't decode to `user:pass`) are collected and
+// returned alongside the hosts that parsed successfully, rather than
+// failing the whole secret.
+func FromDockerConfigJSON(secret []byte) (map[string]PassKeyChain, error) {
+	var parsed pullSecretAuths
+	if err := json.Unmarshal(secret, &parsed); err != nil {
+		return nil, fmt.Errorf("unmarshal dockerconfigjson: %w", err)
+	}
+
+	keychains := make(map[string]PassKeyChain, len(parsed.Auths))
+	var errs []string
+
+	for host, entry := range parsed.Auths {
+		kc, err := FromBase64(entry.Auth)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", host, err))
+			continue
+		}
+		if kc == emptyPassKeyChain {
+			errs = append(errs, fmt.Sprintf("%s: auth does not decode to user:pass", host))
+			continue
+		}
+
+		keychains[normalizeRegistryHost(host)] = kc
+	}
+
+	if len(errs) > 0 {
+		return keychains, fmt.Errorf("invalid auth entries: %s", strings.Join(errs, "; "))
+	}
+
+	return keychains, nil
+}
+
+// normalizeRegistryHost maps the legacy Docker Hub index server host to the
+// canonical "docker.io" used elsewhere in this package.
+func normalizeRegistryHost(host string) string {
+	if host == legacyDockerIOHost {
+		return dockerIOHost
+	}
+	return host
+}
+
+// ValidatePullSecret parses `secret` as a `.dockerconfigjson` payload and
+// verifies it carries usable credentials for every host in
+// `requiredRegistries`. It returns an aggregated error listing every
+// missing or malformed required registry, or nil when all are present.
+func ValidatePullSecret(secret []byte, requiredRegistries ...string) error {
+	keychains, parseErr := FromDockerConfigJSON(secret)
+	if keychains == nil {
+		return parseErr
+	}
+
+	var problems []string
+	for _, host := range requiredRegistries {
+		if _, found := keychains[normalizeRegistryHost(host)]; !found {
+			problems = append(problems, host)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("pull secret has missing or malformed credentials for registries: %s", strings.Join(problems, ", "))
+}
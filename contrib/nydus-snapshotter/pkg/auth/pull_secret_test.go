@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func authEntry(user, pass string) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", user, pass)))
+}
+
+func TestFromDockerConfigJSON(t *testing.T) {
+	secret := []byte(fmt.Sprintf(`{"auths":{
+		"registry.example.com": {"auth": "%s"},
+		"https://index.docker.io/v1/": {"auth": "%s"}
+	}}`, authEntry("alice", "hunter2"), authEntry("bob", "swordfish")))
+
+	keychains, err := FromDockerConfigJSON(secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kc, found := keychains["registry.example.com"]
+	if !found || kc.Username != "alice" || kc.Password != "hunter2" {
+		t.Fatalf("registry.example.com credentials not parsed correctly, got %+v, found=%v", kc, found)
+	}
+
+	kc, found = keychains[dockerIOHost]
+	if !found || kc.Username != "bob" || kc.Password != "swordfish" {
+		t.Fatalf("legacy docker.io host not normalized, got %+v, found=%v", kc, found)
+	}
+
+	if _, found := keychains[legacyDockerIOHost]; found {
+		t.Fatalf("legacy host key %q should not survive normalization", legacyDockerIOHost)
+	}
+}
+
+func TestFromDockerConfigJSONInvalidEntry(t *testing.T) {
+	secret := []byte(`{"auths":{
+		"good.example.com": {"auth": "` + authEntry("alice", "hunter2") + `"},
+		"bad.example.com": {"auth": "bm90LWEtdXNlcnBhc3M="}
+	}}`)
+
+	keychains, err := FromDockerConfigJSON(secret)
+	if err == nil {
+		t.Fatal("expected an error for the malformed auth entry")
+	}
+	if !strings.Contains(err.Error(), "bad.example.com") {
+		t.Fatalf("error should name the offending host, got: %v", err)
+	}
+
+	if _, found := keychains["good.example.com"]; !found {
+		t.Fatal("well-formed entries should still parse despite a sibling error")
+	}
+	if _, found := keychains["bad.example.com"]; found {
+		t.Fatal("malformed entry should not appear in the result")
+	}
+}
+
+func TestFromDockerConfigJSONUnmarshalError(t *testing.T) {
+	if _, err := FromDockerConfigJSON([]byte("not json")); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
+
+func TestValidatePullSecret(t *testing.T) {
+	secret := []byte(`{"auths":{
+		"registry.example.com": {"auth": "` + authEntry("alice", "hunter2") + `"}
+	}}`)
+
+	if err := ValidatePullSecret(secret, "registry.example.com"); err != nil {
+		t.Fatalf("expected no error when the required registry is present, got: %v", err)
+	}
+
+	err := ValidatePullSecret(secret, "registry.example.com", "missing.example.com")
+	if err == nil {
+		t.Fatal("expected an error listing the missing registry")
+	}
+	if !strings.Contains(err.Error(), "missing.example.com") {
+		t.Fatalf("error should name the missing registry, got: %v", err)
+	}
+	if strings.Contains(err.Error(), "registry.example.com") {
+		t.Fatalf("error should not flag a registry that is present, got: %v", err)
+	}
+}
+
+func TestValidatePullSecretMalformedWithNoRequiredRegistries(t *testing.T) {
+	if err := ValidatePullSecret([]byte("not json")); err == nil {
+		t.Fatal("a malformed pull secret must not validate just because no registry was required")
+	}
+}
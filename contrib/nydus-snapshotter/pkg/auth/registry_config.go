@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+)
+
+// RegistryConfig carries per-host TLS settings and mirror ordering parsed
+// from a sysregistriesv2-style registries.conf, alongside the PassKeyChain
+// GetRegistryKeyChain resolves for the same host.
+type RegistryConfig struct {
+	Insecure   bool
+	SkipVerify bool
+	CAFile     string
+	ClientCert string
+	ClientKey  string
+
+	// Mirrors lists hosts nydusd should try, in order, before falling
+	// back to the primary registry host.
+	Mirrors []string
+}
+
+// sysregistriesV2 mirrors the `[[registry]]` table of a containers/image
+// registries.conf file.
+type sysregistriesV2 struct {
+	Registry []registryEntry `toml:"registry"`
+}
+
+type registryEntry struct {
+	Prefix     string        `toml:"prefix"`
+	Location   string        `toml:"location"`
+	Insecure   bool          `toml:"insecure"`
+	SkipVerify bool          `toml:"skip-verify"`
+	CAFile     string        `toml:"ca-file"`
+	ClientCert string        `toml:"client-cert"`
+	ClientKey  string        `toml:"client-key"`
+	MirrorList []mirrorEntry `toml:"mirror"`
+}
+
+type mirrorEntry struct {
+	Location string `toml:"location"`
+	Insecure bool   `toml:"insecure"`
+}
+
+// registryConfigsMu guards registryConfigs so a config reload via
+// LoadRegistriesConf can race safely with GetRegistryConfig/
+// MirrorFailoverOrder reads.
+var registryConfigsMu sync.RWMutex
+
+// registryConfigs caches the registries.conf parsed by
+// LoadRegistriesConf, keyed by host/prefix.
+var registryConfigs map[string]RegistryConfig
+
+// LoadRegistriesConf parses a sysregistriesv2 registries.conf file (the
+// format used by containers/image) at `path` and makes its per-host TLS and
+// mirror settings available to GetRegistryConfig.
+func LoadRegistriesConf(path string) error {
+	var parsed sysregistriesV2
+	if _, err := toml.DecodeFile(path, &parsed); err != nil {
+		return err
+	}
+
+	configs := make(map[string]RegistryConfig, len(parsed.Registry))
+	for _, reg := range parsed.Registry {
+		host := reg.Prefix
+		if host == "" {
+			host = reg.Location
+		}
+		if host == "" {
+			continue
+		}
+
+		mirrors := make([]string, 0, len(reg.MirrorList))
+		for _, m := range reg.MirrorList {
+			if m.Location == "" {
+				continue
+			}
+			mirrors = append(mirrors, m.Location)
+		}
+
+		configs[host] = RegistryConfig{
+			Insecure:   reg.Insecure,
+			SkipVerify: reg.SkipVerify,
+			CAFile:     reg.CAFile,
+			ClientCert: reg.ClientCert,
+			ClientKey:  reg.ClientKey,
+			Mirrors:    mirrors,
+		}
+	}
+
+	registryConfigsMu.Lock()
+	registryConfigs = configs
+	registryConfigsMu.Unlock()
+
+	logrus.WithField("path", path).WithField("registries", len(configs)).Info("loaded registries.conf")
+
+	return nil
+}
+
+// GetRegistryConfig returns the TLS and mirror settings parsed for `host`
+// from the loaded registries.conf, or a zero-value RegistryConfig
+// (no mirrors, verified TLS) when nothing was configured for it.
+func GetRegistryConfig(host string) RegistryConfig {
+	registryConfigsMu.RLock()
+	defer registryConfigsMu.RUnlock()
+	return registryConfigs[host]
+}
+
+// MirrorFailoverOrder returns the hosts nydusd should try for `host`, in
+// order: configured mirrors first, then the primary registry host itself,
+// matching sysregistriesv2 mirror-failover semantics.
+func MirrorFailoverOrder(host string) []string {
+	cfg := GetRegistryConfig(host)
+	return append(append([]string{}, cfg.Mirrors...), host)
+}
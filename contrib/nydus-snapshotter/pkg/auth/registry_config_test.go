@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2020. Ant Group. All rights reserved.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testRegistriesConf = `
+[[registry]]
+prefix = "registry.example.com"
+insecure = true
+skip-verify = true
+ca-file = "/etc/ssl/example-ca.pem"
+
+[[registry.mirror]]
+location = "mirror1.example.com"
+
+[[registry.mirror]]
+location = "mirror2.example.com"
+
+[[registry]]
+location = "docker.io"
+`
+
+func TestLoadRegistriesConf(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "registries.conf")
+	if err := os.WriteFile(path, []byte(testRegistriesConf), 0o600); err != nil {
+		t.Fatalf("write registries.conf: %v", err)
+	}
+
+	if err := LoadRegistriesConf(path); err != nil {
+		t.Fatalf("LoadRegistriesConf: %v", err)
+	}
+
+	cfg := GetRegistryConfig("registry.example.com")
+	if !cfg.Insecure || !cfg.SkipVerify || cfg.CAFile != "/etc/ssl/example-ca.pem" {
+		t.Fatalf("unexpected RegistryConfig for registry.example.com: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Mirrors, []string{"mirror1.example.com", "mirror2.example.com"}) {
+		t.Fatalf("unexpected mirror list: %+v", cfg.Mirrors)
+	}
+
+	dockerIOCfg := GetRegistryConfig("docker.io")
+	if dockerIOCfg.Insecure {
+		t.Fatalf("docker.io entry should default to a secure registry, got: %+v", dockerIOCfg)
+	}
+}
+
+func TestGetRegistryConfigUnknownHost(t *testing.T) {
+	cfg := GetRegistryConfig("unconfigured.example.com")
+	if cfg.Insecure || len(cfg.Mirrors) != 0 {
+		t.Fatalf("unconfigured host should return the zero value, got: %+v", cfg)
+	}
+}
+
+func TestMirrorFailoverOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "registries.conf")
+	if err := os.WriteFile(path, []byte(testRegistriesConf), 0o600); err != nil {
+		t.Fatalf("write registries.conf: %v", err)
+	}
+	if err := LoadRegistriesConf(path); err != nil {
+		t.Fatalf("LoadRegistriesConf: %v", err)
+	}
+
+	order := MirrorFailoverOrder("registry.example.com")
+	want := []string{"mirror1.example.com", "mirror2.example.com", "registry.example.com"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("mirror failover order = %v, want %v", order, want)
+	}
+
+	order = MirrorFailoverOrder("unconfigured.example.com")
+	want = []string{"unconfigured.example.com"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("mirror failover order with no mirrors = %v, want %v", order, want)
+	}
+}